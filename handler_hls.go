@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/assets"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/hls"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/jobs"
+)
+
+// processHLSJob is the HLS equivalent of processTranscodeAndUploadJob's
+// single-file faststart path: it produces a multi-bitrate ladder, uploads
+// every rendition under a per-video prefix, and records the master playlist
+// as a KindHLSMaster asset. Portrait vs. landscape ladders are picked from
+// the same aspect-ratio classification processTranscodeAndUploadJob uses.
+// It returns the playback URL from signHLSMasterPlaylist.
+func (cfg *apiConfig) processHLSJob(ctx context.Context, job jobs.TranscodeAndUploadJob, report func(jobs.Progress)) (string, error) {
+	aspectRatio, err := getVideoAspectRatio(job.SourcePath)
+	if err != nil {
+		return "", fmt.Errorf("error: failed to determine aspect ratio: %s", err)
+	}
+
+	ladder := hls.LandscapeLadder
+	if aspectRatio == "9:16" {
+		ladder = hls.PortraitLadder
+	}
+
+	// Both ladders store each rendition's vertical size in Height, so the
+	// source's own height is the right bound for either orientation.
+	_, height, err := videoDimensions(job.SourcePath)
+	if err != nil {
+		return "", fmt.Errorf("error: failed to read video dimensions: %s", err)
+	}
+	ladder = hls.SelectLadder(ladder, height)
+
+	outputDir, err := os.MkdirTemp("", "tubely-hls-*")
+	if err != nil {
+		return "", fmt.Errorf("error: failed to create hls output dir: %s", err)
+	}
+	defer os.RemoveAll(outputDir)
+
+	report(jobs.Progress{Stage: jobs.StageTranscoding})
+	prefix := path.Join("hls", job.VideoID.String())
+	masterKey, outputs, err := hls.Transcode(job.SourcePath, outputDir, prefix, ladder)
+	if err != nil {
+		return "", fmt.Errorf("error: failed to transcode hls ladder: %s", err)
+	}
+
+	var variantKeys []string
+	for i, out := range outputs {
+		f, err := os.Open(out.Path)
+		if err != nil {
+			return "", fmt.Errorf("error: failed to open hls output %s: %s", out.Path, err)
+		}
+		err = cfg.fileStore.Put(ctx, out.Key, f, out.ContentType)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("error: failed to upload hls output %s: %s", out.Key, err)
+		}
+		report(jobs.Progress{PercentComplete: float64(i+1) / float64(len(outputs)) * 100, Stage: jobs.StageUploading})
+
+		if strings.HasSuffix(out.Key, ".m3u8") && out.Key != masterKey {
+			variantKeys = append(variantKeys, out.Key)
+		}
+	}
+
+	if err := cfg.assets.Put(assets.Asset{
+		VideoID:     job.VideoID,
+		Kind:        assets.KindHLSMaster,
+		Backend:     cfg.storageBackend,
+		Bucket:      cfg.storageBucket,
+		Key:         masterKey,
+		CDNDomain:   cfg.cdnDomain,
+		ContentType: "application/vnd.apple.mpegurl",
+		VariantKeys: variantKeys,
+	}); err != nil {
+		return "", fmt.Errorf("error: failed to record hls master asset: %s", err)
+	}
+
+	if _, err := cfg.uploadPeaks(ctx, job.VideoID, job.SourcePath); err != nil {
+		return "", fmt.Errorf("error: failed to generate waveform peaks: %s", err)
+	}
+
+	masterURL, err := cfg.signHLSMasterPlaylist(assets.Asset{VideoID: job.VideoID, Key: masterKey})
+	if err != nil {
+		return "", fmt.Errorf("error: failed to sign hls master playlist: %s", err)
+	}
+	return masterURL, nil
+}
+
+// signHLSMasterPlaylist returns the URL a client should use to play hlsAsset.
+// When a CDN is configured, cfg.fileStore is wrapped in a CloudFrontStore, so
+// PresignGet already returns a signed CloudFront URL for the master playlist.
+// Otherwise it points at handlerServeHLSMasterPlaylist, which rewrites each
+// variant reference to its own presigned URL.
+func (cfg *apiConfig) signHLSMasterPlaylist(hlsAsset assets.Asset) (string, error) {
+	if cfg.cdnDomain != "" {
+		return cfg.fileStore.PresignGet(context.Background(), hlsAsset.Key, time.Minute*5)
+	}
+	return fmt.Sprintf("/api/videos/%s/hls/master.m3u8", hlsAsset.VideoID), nil
+}
+
+// handlerServeHLSMasterPlaylist rewrites the stored master playlist's
+// variant references to point at handlerServeHLSVariantPlaylist and serves
+// the result directly, so a client with only the returned VideoURL can start
+// an HLS session without every segment needing its own signed link handed
+// out up front.
+//
+// This endpoint (and handlerServeHLSVariantPlaylist below it) is meant for
+// the local/MinIO dev backends, where production traffic is expected to run
+// behind CloudFront instead (see signHLSMasterPlaylist).
+func (cfg *apiConfig) handlerServeHLSMasterPlaylist(w http.ResponseWriter, r *http.Request) {
+	videoID, err := parseVideoID(r.PathValue("videoID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid video ID", err)
+		return
+	}
+
+	hlsAsset, err := cfg.assets.Get(videoID, assets.KindHLSMaster)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "No HLS playlist for this video", err)
+		return
+	}
+
+	body, err := cfg.fileStore.Get(r.Context(), hlsAsset.Key)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't read master playlist", err)
+		return
+	}
+	defer body.Close()
+	playlist, err := io.ReadAll(body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't read master playlist", err)
+		return
+	}
+
+	rewritten := string(playlist)
+	for _, variantKey := range hlsAsset.VariantKeys {
+		variantName := path.Base(variantKey)
+		variantURL := fmt.Sprintf("/api/videos/%s/hls/%s", videoID, variantName)
+		rewritten = strings.ReplaceAll(rewritten, variantName, variantURL)
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Write([]byte(rewritten))
+}
+
+// handlerServeHLSVariantPlaylist rewrites one HLS variant playlist's segment
+// references into presigned URLs and serves the result, the same way
+// handlerServeHLSMasterPlaylist does for the master one level up. Without
+// this, a client fetching the raw variant playlist off the file store would
+// be handed back unsigned .ts segment names that 403 against a private
+// bucket (or fail SignedFileHandler's expires/sig check on the local
+// backend).
+func (cfg *apiConfig) handlerServeHLSVariantPlaylist(w http.ResponseWriter, r *http.Request) {
+	videoID, err := parseVideoID(r.PathValue("videoID"))
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid video ID", err)
+		return
+	}
+
+	hlsAsset, err := cfg.assets.Get(videoID, assets.KindHLSMaster)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "No HLS playlist for this video", err)
+		return
+	}
+
+	variantName := r.PathValue("variant")
+	variantKey := path.Join(path.Dir(hlsAsset.Key), variantName)
+	known := false
+	for _, k := range hlsAsset.VariantKeys {
+		if k == variantKey {
+			known = true
+			break
+		}
+	}
+	if !known {
+		respondWithError(w, http.StatusNotFound, "Unknown HLS variant", nil)
+		return
+	}
+
+	body, err := cfg.fileStore.Get(r.Context(), variantKey)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't read variant playlist", err)
+		return
+	}
+	defer body.Close()
+	playlist, err := io.ReadAll(body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't read variant playlist", err)
+		return
+	}
+
+	lines := strings.Split(string(playlist), "\n")
+	for i, line := range lines {
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		segmentKey := path.Join(path.Dir(variantKey), line)
+		presignedURL, err := cfg.fileStore.PresignGet(r.Context(), segmentKey, time.Minute*5)
+		if err != nil {
+			respondWithError(w, http.StatusInternalServerError, "Couldn't sign segment", err)
+			return
+		}
+		lines[i] = presignedURL
+	}
+
+	w.Header().Set("Content-Type", "application/vnd.apple.mpegurl")
+	w.Write([]byte(strings.Join(lines, "\n")))
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/jobs"
+)
+
+// handlerGetUploadProgress streams {percent_complete, stage, url} updates
+// for a transcode job as Server-Sent Events until the job reaches a
+// terminal stage (done or failed) or the client disconnects. Only the user
+// who owns the job may subscribe to it.
+func (cfg *apiConfig) handlerGetUploadProgress(w http.ResponseWriter, r *http.Request) {
+	jobID := r.PathValue("jobID")
+	if jobID == "" {
+		respondWithError(w, http.StatusBadRequest, "Missing job ID", nil)
+		return
+	}
+
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get JWT from header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	job, _, err := cfg.jobQueue.Job(jobID)
+	if err != nil {
+		respondWithError(w, http.StatusNotFound, "Couldn't find upload job", err)
+		return
+	}
+	if job.UserID != userID {
+		respondWithError(w, http.StatusUnauthorized, "You don't own this upload job", nil)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming unsupported", nil)
+		return
+	}
+
+	updates, unsubscribe := cfg.jobQueue.Subscribe(jobID)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case progress, ok := <-updates:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(progress)
+			if err != nil {
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", data)
+			flusher.Flush()
+
+			if progress.Stage == jobs.StageDone || progress.Stage == jobs.StageFailed {
+				return
+			}
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"database/sql"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -12,16 +13,18 @@ import (
 	"os"
 	"os/exec"
 	"path"
-	"strings"
 	"time"
 
-	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/assets"
 	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
 	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/database"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/jobs"
 	"github.com/google/uuid"
 )
 
-func getVideoAspectRatio(filePath string) (string, error) {
+// videoDimensions shells out to ffprobe to read the pixel width and height
+// of filePath's first stream.
+func videoDimensions(filePath string) (width, height int, err error) {
 	cmdPtr := exec.Command("ffprobe",
 		"-v", "error",
 		"-print_format", "json",
@@ -31,9 +34,8 @@ func getVideoAspectRatio(filePath string) (string, error) {
 
 	var resBuffer bytes.Buffer
 	cmdPtr.Stdout = &resBuffer
-	err := cmdPtr.Run()
-	if err != nil {
-		return "", fmt.Errorf("ffprobe error: %v", err)
+	if err := cmdPtr.Run(); err != nil {
+		return 0, 0, fmt.Errorf("ffprobe error: %v", err)
 	}
 
 	var output struct {
@@ -42,17 +44,22 @@ func getVideoAspectRatio(filePath string) (string, error) {
 			Height int `json:"height"`
 		} `json:"streams"`
 	}
-	err = json.Unmarshal(resBuffer.Bytes(), &output)
-	if err != nil {
-		return "", fmt.Errorf("error: failed to unmarshal ffmpeg res: %s", err)
+	if err := json.Unmarshal(resBuffer.Bytes(), &output); err != nil {
+		return 0, 0, fmt.Errorf("error: failed to unmarshal ffmpeg res: %s", err)
 	}
 
 	if len(output.Streams) == 0 {
-		return "", errors.New("error: ffmpeg result is empty")
+		return 0, 0, errors.New("error: ffmpeg result is empty")
 	}
 
-	width := output.Streams[0].Width
-	height := output.Streams[0].Height
+	return output.Streams[0].Width, output.Streams[0].Height, nil
+}
+
+func getVideoAspectRatio(filePath string) (string, error) {
+	width, height, err := videoDimensions(filePath)
+	if err != nil {
+		return "", err
+	}
 
 	if width == 16*height/9 {
 		return "16:9", nil
@@ -62,29 +69,64 @@ func getVideoAspectRatio(filePath string) (string, error) {
 	return "other", nil
 }
 
-func processVideoForFastStart(filePath string) (string, error) {
+// processVideoForFastStart pipes filePath into ffmpeg's stdin so report can
+// be called with byte-level progress as the transcode reads through the
+// source file, and writes a faststart MP4 to a sibling ".processing" path.
+func processVideoForFastStart(filePath string, report func(read, total int64)) (string, error) {
 	outputFilePath := filePath + ".processing"
 
+	inFile, err := os.Open(filePath)
+	if err != nil {
+		return "", fmt.Errorf("error: failed to open %s for transcoding: %s", filePath, err)
+	}
+	defer inFile.Close()
+	info, err := inFile.Stat()
+	if err != nil {
+		return "", fmt.Errorf("error: failed to stat %s: %s", filePath, err)
+	}
+
 	cmdPtr := exec.Command("ffmpeg",
-		"-i", filePath,
+		"-i", "pipe:0",
 		"-c", "copy",
 		"-movflags", "faststart",
 		"-f", "mp4",
 		outputFilePath,
 	)
-
-	err := cmdPtr.Run()
+	stdin, err := cmdPtr.StdinPipe()
 	if err != nil {
+		return "", fmt.Errorf("error: failed to open ffmpeg stdin: %s", err)
+	}
+	if err := cmdPtr.Start(); err != nil {
+		return "", fmt.Errorf("error: failed to start ffmpeg: %s", err)
+	}
+
+	progress := jobs.NewProgressReader(inFile, info.Size(), report)
+	copyErr := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(stdin, progress)
+		stdin.Close()
+		copyErr <- err
+	}()
+
+	if err := <-copyErr; err != nil {
+		return "", fmt.Errorf("error: failed to stream video into ffmpeg: %s", err)
+	}
+	if err := cmdPtr.Wait(); err != nil {
 		return "", fmt.Errorf("error: ffmpeg command error: %s", err)
 	}
 
 	return outputFilePath, nil
 }
 
+// parseVideoID parses the "videoID" path value shared by the video upload
+// and playback routes.
+func parseVideoID(videoIDString string) (uuid.UUID, error) {
+	return uuid.Parse(videoIDString)
+}
+
 func (cfg *apiConfig) handlerUploadVideo(w http.ResponseWriter, r *http.Request) {
 	// Get video ID from URL path
-	videoIDString := r.PathValue("videoID")
-	videoID, err := uuid.Parse(videoIDString)
+	videoID, err := parseVideoID(r.PathValue("videoID"))
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Invalid video ID", err)
 		return
@@ -116,9 +158,8 @@ func (cfg *apiConfig) handlerUploadVideo(w http.ResponseWriter, r *http.Request)
 
 	// Read video into memory and get the file/header from FormFile
 	// Get information we want like content type to check if it is a video
-	maxUploadSize := 1 << 30 // 1 GB
-	r.Body = http.MaxBytesReader(w, r.Body, int64(maxUploadSize))
-	err = r.ParseMultipartForm(int64(maxUploadSize))
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxUploadSize)
+	err = r.ParseMultipartForm(cfg.maxUploadSize)
 	if err != nil {
 		respondWithError(w, http.StatusBadRequest, "Exceeded max upload file size", err)
 		return
@@ -140,38 +181,66 @@ func (cfg *apiConfig) handlerUploadVideo(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Create temp file so we can copy file contents into it and process it
-	//
+	// Persist the raw upload to a temp file so the request can return right
+	// away; the transcode and file store upload happen on a worker.
 	tempFile, err := os.CreateTemp("", "tubely-upload.mp4")
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "failed to create temp file", err)
 		return
 	}
-	defer os.Remove(tempFile.Name())
 	defer tempFile.Close()
 	_, err = io.Copy(tempFile, file)
 	if err != nil {
+		os.Remove(tempFile.Name())
 		respondWithError(w, http.StatusInternalServerError, "failed to copy file to disk", err)
 		return
 	}
-	_, err = tempFile.Seek(0, io.SeekStart)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Could not reset file pointer", err)
+
+	job := jobs.TranscodeAndUploadJob{
+		VideoID:    videoID,
+		UserID:     userID,
+		SourcePath: tempFile.Name(),
+		MediaType:  mediaType,
+	}
+	if err := cfg.jobQueue.Enqueue(job); err != nil {
+		os.Remove(tempFile.Name())
+		respondWithError(w, http.StatusInternalServerError, "Couldn't enqueue transcode job", err)
 		return
 	}
 
-	// Process video with ffmpeg to move video metadata to start of file
-	// and build a path for upload to S3 based on aspect ratiot of video
-	processedFilePath, err := processVideoForFastStart(tempFile.Name())
+	respondWithJSON(w, http.StatusAccepted, struct {
+		JobID string `json:"job_id"`
+	}{JobID: job.ID})
+}
+
+// processTranscodeAndUploadJob is the jobs.ProcessFunc run by cfg.jobQueue's
+// workers: it faststarts the uploaded file, classifies its aspect ratio,
+// uploads the result to the file store, records it (and its waveform peaks)
+// in cfg.assets, and returns a presigned URL for it. The video row itself is
+// not modified. When HLS is enabled it hands the job to processHLSJob instead.
+func (cfg *apiConfig) processTranscodeAndUploadJob(ctx context.Context, job jobs.TranscodeAndUploadJob, report func(jobs.Progress)) (string, error) {
+	defer os.Remove(job.SourcePath)
+
+	if cfg.hlsEnabled {
+		return cfg.processHLSJob(ctx, job, report)
+	}
+
+	processedFilePath, err := processVideoForFastStart(job.SourcePath, func(read, total int64) {
+		percent := 0.0
+		if total > 0 {
+			percent = float64(read) / float64(total) * 100
+		}
+		report(jobs.Progress{PercentComplete: percent, Stage: jobs.StageTranscoding})
+	})
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to process video", err)
-		return
+		return "", fmt.Errorf("error: failed to process video: %s", err)
 	}
+	defer os.Remove(processedFilePath)
+
 	directory := ""
 	aspectRatio, err := getVideoAspectRatio(processedFilePath)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Error determining aspect ratio", err)
-		return
+		return "", fmt.Errorf("error: failed to determine aspect ratio: %s", err)
 	}
 	switch aspectRatio {
 	case "16:9":
@@ -182,80 +251,119 @@ func (cfg *apiConfig) handlerUploadVideo(w http.ResponseWriter, r *http.Request)
 		directory = "other"
 	}
 
-	// Since we only got returned file path earlier, we need to open up the
-	// processed file so we can upload its contents to S3
 	pFile, err := os.Open(processedFilePath)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "failed to open processed file", err)
-		return
+		return "", fmt.Errorf("error: failed to open processed file: %s", err)
 	}
-	defer os.Remove(processedFilePath)
 	defer pFile.Close()
+	info, err := pFile.Stat()
+	if err != nil {
+		return "", fmt.Errorf("error: failed to stat processed file: %s", err)
+	}
 
-	key := getAssetPath(mediaType)
+	key := getAssetPath(job.MediaType)
 	key = path.Join(directory, key)
-	_, err = cfg.s3Client.PutObject(r.Context(), &s3.PutObjectInput{
-		Bucket:      &cfg.s3Bucket,
-		Key:         &key,
-		Body:        pFile,
-		ContentType: &mediaType,
+
+	uploadProgress := jobs.NewProgressReader(pFile, info.Size(), func(read, total int64) {
+		percent := 0.0
+		if total > 0 {
+			percent = float64(read) / float64(total) * 100
+		}
+		report(jobs.Progress{PercentComplete: percent, Stage: jobs.StageUploading})
 	})
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't put object into S3", err)
-		return
+	if err := cfg.fileStore.Put(ctx, key, uploadProgress, job.MediaType); err != nil {
+		return "", fmt.Errorf("error: failed to put object into file store: %s", err)
+	}
+	if err := cfg.assets.Put(assets.Asset{
+		VideoID:     job.VideoID,
+		Kind:        assets.KindVideo,
+		Backend:     cfg.storageBackend,
+		Bucket:      cfg.storageBucket,
+		Key:         key,
+		CDNDomain:   cfg.cdnDomain,
+		ContentType: job.MediaType,
+		Size:        info.Size(),
+	}); err != nil {
+		return "", fmt.Errorf("error: failed to record video asset: %s", err)
 	}
 
-	// s3FileURL := cfg.getVideoURL(key)
-	// videoMetadata.VideoURL = &s3FileURL
-	bucketAndKey := cfg.s3Bucket + "," + key
-	videoMetadata.VideoURL = &bucketAndKey
-
-	err = cfg.db.UpdateVideo(videoMetadata)
-	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't update video", err)
-		return
+	if _, err := cfg.uploadPeaks(ctx, job.VideoID, processedFilePath); err != nil {
+		return "", fmt.Errorf("error: failed to generate waveform peaks: %s", err)
 	}
 
-	signedVideo, err := cfg.dbVideoToSignedVideo(videoMetadata)
+	videoURL, err := cfg.fileStore.PresignGet(ctx, key, time.Minute*5)
 	if err != nil {
-		respondWithError(w, http.StatusInternalServerError, "Couldn't sign video", err)
-		return
+		return "", fmt.Errorf("error: failed to generate presigned URL: %s", err)
 	}
-
-	respondWithJSON(w, http.StatusOK, signedVideo)
+	return videoURL, nil
 }
 
-func generatePresignedURL(s3Client *s3.Client, bucket, key string, expireTime time.Duration) (string, error) {
-	s3PresignClient := s3.NewPresignClient(s3Client)
-
-	presignedObject, err := s3PresignClient.PresignGetObject(context.TODO(), &s3.GetObjectInput{
-		Bucket: &bucket,
-		Key:    &key,
-	}, s3.WithPresignExpires(expireTime))
+// uploadPeaks generates a waveform peaks file for processedFilePath and
+// uploads it alongside the video under a "peaks/" prefix, recording it as a
+// video_assets row so it can be looked up and presigned independently. Not
+// every upload has an audio track (silent screen recordings, muted clips),
+// so a decode failure here just skips the peaks asset instead of failing
+// the whole job.
+func (cfg *apiConfig) uploadPeaks(ctx context.Context, videoID uuid.UUID, processedFilePath string) (string, error) {
+	peaks, err := generatePeaks(processedFilePath)
 	if err != nil {
-		return "", fmt.Errorf("error: failed to get presign object: %s", err)
+		fmt.Println("skipping waveform peaks for video", videoID, "- no audio track or ffmpeg error:", err)
+		return "", nil
 	}
 
-	return presignedObject.URL, nil
+	peaksKey := path.Join("peaks", videoID.String()+".bin")
+	if err := cfg.fileStore.Put(ctx, peaksKey, bytes.NewReader(peaks), "application/octet-stream"); err != nil {
+		return "", fmt.Errorf("error: failed to put peaks into file store: %s", err)
+	}
+	if err := cfg.assets.Put(assets.Asset{
+		VideoID:     videoID,
+		Kind:        assets.KindPeaks,
+		Backend:     cfg.storageBackend,
+		Bucket:      cfg.storageBucket,
+		Key:         peaksKey,
+		CDNDomain:   cfg.cdnDomain,
+		ContentType: "application/octet-stream",
+		Size:        int64(len(peaks)),
+	}); err != nil {
+		return "", fmt.Errorf("error: failed to record peaks asset: %s", err)
+	}
+	return peaksKey, nil
 }
 
 func (cfg *apiConfig) dbVideoToSignedVideo(video database.Video) (database.Video, error) {
-	if video.VideoURL == nil {
-		return video, nil
+	hlsAsset, err := cfg.assets.Get(video.ID, assets.KindHLSMaster)
+	switch {
+	case err == nil:
+		hlsURL, err := cfg.signHLSMasterPlaylist(hlsAsset)
+		if err != nil {
+			return video, fmt.Errorf("error: failed to sign hls master playlist: %v", err)
+		}
+		video.VideoURL = &hlsURL
+	case errors.Is(err, sql.ErrNoRows):
+		videoAsset, err := cfg.assets.Get(video.ID, assets.KindVideo)
+		if err == nil {
+			presignedURL, err := cfg.fileStore.PresignGet(context.Background(), videoAsset.Key, time.Minute*5)
+			if err != nil {
+				return video, fmt.Errorf("error: failed to generate presigned URL: %v", err)
+			}
+			video.VideoURL = &presignedURL
+		} else if !errors.Is(err, sql.ErrNoRows) {
+			return video, fmt.Errorf("error: failed to look up video asset: %v", err)
+		}
+	default:
+		return video, fmt.Errorf("error: failed to look up hls master asset: %v", err)
 	}
 
-	split := strings.Split(*video.VideoURL, ",")
-	if len(split) < 2 {
-		return video, errors.New("error: invalid video url")
+	peaksAsset, err := cfg.assets.Get(video.ID, assets.KindPeaks)
+	if err == nil {
+		presignedPeaksURL, err := cfg.fileStore.PresignGet(context.Background(), peaksAsset.Key, time.Minute*5)
+		if err != nil {
+			return video, fmt.Errorf("error: failed to generate presigned peaks URL: %v", err)
+		}
+		video.PeaksURL = &presignedPeaksURL
+	} else if !errors.Is(err, sql.ErrNoRows) {
+		return video, fmt.Errorf("error: failed to look up peaks asset: %v", err)
 	}
 
-	bucket := split[0]
-	key := split[1]
-
-	presignedURL, err := generatePresignedURL(cfg.s3Client, bucket, key, time.Minute*5)
-	if err != nil {
-		return video, fmt.Errorf("error: failed to generate presigned URL: %v", err)
-	}
-	video.VideoURL = &presignedURL
 	return video, nil
 }
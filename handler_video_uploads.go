@@ -0,0 +1,346 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/auth"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/filestore"
+	"github.com/bootdotdev/learn-file-storage-s3-golang-starter/internal/jobs"
+	"github.com/google/uuid"
+)
+
+// uploadSession tracks one in-progress resumable upload: the parts uploaded
+// so far and enough context to finish or abort it.
+type uploadSession struct {
+	id        string
+	videoID   uuid.UUID
+	userID    uuid.UUID
+	key       string
+	uploadID  string
+	mediaType string
+	createdAt time.Time
+
+	mu sync.Mutex
+	// parts is keyed by part number so a retried PUT for the same part just
+	// overwrites its earlier entry instead of producing a duplicate.
+	parts map[int32]filestore.CompletedPart
+}
+
+// videoUploadManager tracks resumable upload sessions in memory and sweeps
+// away ones that were never completed.
+type videoUploadManager struct {
+	mu       sync.Mutex
+	sessions map[string]*uploadSession
+	maxAge   time.Duration
+}
+
+func newVideoUploadManager(maxAge time.Duration) *videoUploadManager {
+	return &videoUploadManager{
+		sessions: make(map[string]*uploadSession),
+		maxAge:   maxAge,
+	}
+}
+
+func (m *videoUploadManager) put(s *uploadSession) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sessions[s.id] = s
+}
+
+func (m *videoUploadManager) get(id string) (*uploadSession, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s, ok := m.sessions[id]
+	return s, ok
+}
+
+func (m *videoUploadManager) remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, id)
+}
+
+// sweepStaleUploads runs until ctx is cancelled, periodically aborting and
+// forgetting upload sessions older than maxAge.
+func (m *videoUploadManager) sweepStaleUploads(ctx context.Context, store filestore.MultipartPutter) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.mu.Lock()
+			var stale []*uploadSession
+			for id, s := range m.sessions {
+				if time.Since(s.createdAt) > m.maxAge {
+					stale = append(stale, s)
+					delete(m.sessions, id)
+				}
+			}
+			m.mu.Unlock()
+
+			for _, s := range stale {
+				if err := store.AbortMultipartUpload(ctx, s.key, s.uploadID); err != nil {
+					fmt.Println("failed to abort stale upload", s.id, err)
+				}
+			}
+		}
+	}
+}
+
+type createVideoUploadRequest struct {
+	VideoID   uuid.UUID `json:"video_id"`
+	MediaType string    `json:"media_type"`
+}
+
+type createVideoUploadResponse struct {
+	UploadID string `json:"upload_id"`
+}
+
+// handlerCreateVideoUpload starts a resumable upload: POST /api/video_uploads.
+func (cfg *apiConfig) handlerCreateVideoUpload(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get JWT from header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	var req createVideoUploadRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request body", err)
+		return
+	}
+	if req.MediaType != "video/mp4" {
+		respondWithError(w, http.StatusBadRequest, "incorrect file type", nil)
+		return
+	}
+
+	videoMetadata, err := cfg.db.GetVideo(req.VideoID)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Failed to get video from database", err)
+		return
+	}
+	if videoMetadata.UserID != userID {
+		respondWithError(w, http.StatusUnauthorized, "Video is not yours!", err)
+		return
+	}
+
+	multipartStore, ok := cfg.fileStore.(filestore.MultipartPutter)
+	if !ok {
+		respondWithError(w, http.StatusNotImplemented, "Configured file store doesn't support resumable uploads", nil)
+		return
+	}
+
+	stagingKey := "staging/" + uuid.NewString()
+	backendUploadID, err := multipartStore.CreateMultipartUpload(r.Context(), stagingKey, req.MediaType)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't start multipart upload", err)
+		return
+	}
+
+	session := &uploadSession{
+		id:        uuid.NewString(),
+		videoID:   req.VideoID,
+		userID:    userID,
+		key:       stagingKey,
+		uploadID:  backendUploadID,
+		mediaType: req.MediaType,
+		createdAt: time.Now(),
+		parts:     make(map[int32]filestore.CompletedPart),
+	}
+	cfg.videoUploads.put(session)
+
+	respondWithJSON(w, http.StatusCreated, createVideoUploadResponse{UploadID: session.id})
+}
+
+// handlerUploadVideoPart accepts one chunk of a resumable upload:
+// PUT /api/video_uploads/{id}/parts/{n}.
+func (cfg *apiConfig) handlerUploadVideoPart(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get JWT from header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	session, ok := cfg.videoUploads.get(r.PathValue("id"))
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown upload", nil)
+		return
+	}
+	if session.userID != userID {
+		respondWithError(w, http.StatusUnauthorized, "Upload is not yours!", nil)
+		return
+	}
+
+	partNumber, err := strconv.ParseInt(r.PathValue("n"), 10, 32)
+	if err != nil || partNumber < 1 {
+		respondWithError(w, http.StatusBadRequest, "Invalid part number", err)
+		return
+	}
+
+	multipartStore, ok := cfg.fileStore.(filestore.MultipartPutter)
+	if !ok {
+		respondWithError(w, http.StatusNotImplemented, "Configured file store doesn't support resumable uploads", nil)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, cfg.maxUploadSize)
+	etag, err := multipartStore.UploadPart(r.Context(), session.key, session.uploadID, int32(partNumber), r.Body)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't upload part", err)
+		return
+	}
+
+	session.mu.Lock()
+	session.parts[int32(partNumber)] = filestore.CompletedPart{
+		PartNumber: int32(partNumber),
+		ETag:       etag,
+	}
+	session.mu.Unlock()
+
+	respondWithJSON(w, http.StatusOK, struct{}{})
+}
+
+// handlerCompleteVideoUpload finishes a resumable upload:
+// POST /api/video_uploads/{id}/complete. It assembles the object in S3, then
+// runs the same ffprobe/faststart pipeline handlerUploadVideo uses on a
+// single-request upload.
+func (cfg *apiConfig) handlerCompleteVideoUpload(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get JWT from header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	uploadID := r.PathValue("id")
+	session, ok := cfg.videoUploads.get(uploadID)
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown upload", nil)
+		return
+	}
+	if session.userID != userID {
+		respondWithError(w, http.StatusUnauthorized, "Upload is not yours!", nil)
+		return
+	}
+
+	session.mu.Lock()
+	parts := make([]filestore.CompletedPart, 0, len(session.parts))
+	for _, part := range session.parts {
+		parts = append(parts, part)
+	}
+	session.mu.Unlock()
+	sort.Slice(parts, func(i, j int) bool { return parts[i].PartNumber < parts[j].PartNumber })
+
+	multipartStore, ok := cfg.fileStore.(filestore.MultipartPutter)
+	if !ok {
+		respondWithError(w, http.StatusNotImplemented, "Configured file store doesn't support resumable uploads", nil)
+		return
+	}
+	if err := multipartStore.CompleteMultipartUpload(r.Context(), session.key, session.uploadID, parts); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't complete multipart upload", err)
+		return
+	}
+	cfg.videoUploads.remove(uploadID)
+
+	assembled, err := cfg.fileStore.Get(r.Context(), session.key)
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't read assembled upload", err)
+		return
+	}
+	defer assembled.Close()
+
+	tempFile, err := os.CreateTemp("", "tubely-upload.mp4")
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "failed to create temp file", err)
+		return
+	}
+	defer tempFile.Close()
+	if _, err := io.Copy(tempFile, assembled); err != nil {
+		os.Remove(tempFile.Name())
+		respondWithError(w, http.StatusInternalServerError, "failed to copy assembled upload to disk", err)
+		return
+	}
+
+	if err := cfg.fileStore.Delete(r.Context(), session.key); err != nil {
+		fmt.Println("failed to delete staging object", session.key, err)
+	}
+
+	job := jobs.TranscodeAndUploadJob{
+		VideoID:    session.videoID,
+		UserID:     session.userID,
+		SourcePath: tempFile.Name(),
+		MediaType:  session.mediaType,
+	}
+	if err := cfg.jobQueue.Enqueue(job); err != nil {
+		os.Remove(tempFile.Name())
+		respondWithError(w, http.StatusInternalServerError, "Couldn't enqueue transcode job", err)
+		return
+	}
+
+	respondWithJSON(w, http.StatusAccepted, struct {
+		JobID string `json:"job_id"`
+	}{JobID: job.ID})
+}
+
+// handlerAbortVideoUpload cancels a resumable upload before it's completed.
+func (cfg *apiConfig) handlerAbortVideoUpload(w http.ResponseWriter, r *http.Request) {
+	token, err := auth.GetBearerToken(r.Header)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Couldn't get JWT from header", err)
+		return
+	}
+	userID, err := auth.ValidateJWT(token, cfg.jwtSecret)
+	if err != nil {
+		respondWithError(w, http.StatusUnauthorized, "Invalid JWT", err)
+		return
+	}
+
+	uploadID := r.PathValue("id")
+	session, ok := cfg.videoUploads.get(uploadID)
+	if !ok {
+		respondWithError(w, http.StatusNotFound, "Unknown upload", nil)
+		return
+	}
+	if session.userID != userID {
+		respondWithError(w, http.StatusUnauthorized, "Upload is not yours!", nil)
+		return
+	}
+
+	multipartStore, ok := cfg.fileStore.(filestore.MultipartPutter)
+	if !ok {
+		respondWithError(w, http.StatusNotImplemented, "Configured file store doesn't support resumable uploads", nil)
+		return
+	}
+	if err := multipartStore.AbortMultipartUpload(r.Context(), session.key, session.uploadID); err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Couldn't abort multipart upload", err)
+		return
+	}
+	cfg.videoUploads.remove(uploadID)
+
+	respondWithJSON(w, http.StatusNoContent, struct{}{})
+}
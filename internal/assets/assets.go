@@ -0,0 +1,129 @@
+// Package assets tracks where each uploaded file actually lives, so
+// handlers don't have to encode bucket/key pairs into ad-hoc string columns.
+package assets
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Kind distinguishes the different files tubely stores per video.
+type Kind string
+
+const (
+	KindVideo     Kind = "video"
+	KindPeaks     Kind = "peaks"
+	KindHLSMaster Kind = "hls_master"
+)
+
+// Asset is one row of the video_assets table: everything needed to fetch or
+// deliver a file regardless of which FileStore backend holds it.
+type Asset struct {
+	VideoID     uuid.UUID
+	Kind        Kind
+	Backend     string
+	Bucket      string
+	Key         string
+	CDNDomain   string
+	ContentType string
+	Size        int64
+	ETag        string
+
+	// VariantKeys holds the keys of the per-rendition playlists referenced
+	// by a KindHLSMaster asset's master playlist, so the master's variant
+	// references can be rewritten and requests for a variant checked against
+	// them. Unused for other kinds.
+	VariantKeys []string
+}
+
+// Store persists Assets to the server's SQLite database.
+type Store struct {
+	db *sql.DB
+}
+
+// NewStore opens db and ensures the video_assets table exists.
+func NewStore(db *sql.DB) (*Store, error) {
+	s := &Store{db: db}
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Store) migrate() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS video_assets (
+			video_id TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			backend TEXT NOT NULL,
+			bucket TEXT NOT NULL,
+			key TEXT NOT NULL,
+			cdn_domain TEXT,
+			content_type TEXT,
+			size INTEGER,
+			etag TEXT,
+			variant_keys TEXT,
+			PRIMARY KEY (video_id, kind)
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("assets: failed to create video_assets table: %w", err)
+	}
+	return nil
+}
+
+// Put upserts the asset row for (asset.VideoID, asset.Kind).
+func (s *Store) Put(asset Asset) error {
+	_, err := s.db.Exec(`
+		INSERT INTO video_assets (video_id, kind, backend, bucket, key, cdn_domain, content_type, size, etag, variant_keys)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (video_id, kind) DO UPDATE SET
+			backend = excluded.backend,
+			bucket = excluded.bucket,
+			key = excluded.key,
+			cdn_domain = excluded.cdn_domain,
+			content_type = excluded.content_type,
+			size = excluded.size,
+			etag = excluded.etag,
+			variant_keys = excluded.variant_keys
+	`,
+		asset.VideoID.String(), asset.Kind, asset.Backend, asset.Bucket, asset.Key,
+		asset.CDNDomain, asset.ContentType, asset.Size, asset.ETag, strings.Join(asset.VariantKeys, ","),
+	)
+	if err != nil {
+		return fmt.Errorf("assets: failed to upsert asset %s/%s: %w", asset.VideoID, asset.Kind, err)
+	}
+	return nil
+}
+
+// Get looks up the asset row for (videoID, kind). It returns sql.ErrNoRows
+// if no asset of that kind has been recorded for the video yet.
+func (s *Store) Get(videoID uuid.UUID, kind Kind) (Asset, error) {
+	asset := Asset{VideoID: videoID, Kind: kind}
+	var variantKeys string
+	row := s.db.QueryRow(`
+		SELECT backend, bucket, key, cdn_domain, content_type, size, etag, variant_keys
+		FROM video_assets WHERE video_id = ? AND kind = ?
+	`, videoID.String(), kind)
+
+	err := row.Scan(&asset.Backend, &asset.Bucket, &asset.Key, &asset.CDNDomain, &asset.ContentType, &asset.Size, &asset.ETag, &variantKeys)
+	if err != nil {
+		return Asset{}, err
+	}
+	if variantKeys != "" {
+		asset.VariantKeys = strings.Split(variantKeys, ",")
+	}
+	return asset, nil
+}
+
+// Delete removes the asset row for (videoID, kind), if any.
+func (s *Store) Delete(videoID uuid.UUID, kind Kind) error {
+	_, err := s.db.Exec(`DELETE FROM video_assets WHERE video_id = ? AND kind = ?`, videoID.String(), kind)
+	if err != nil {
+		return fmt.Errorf("assets: failed to delete asset %s/%s: %w", videoID, kind, err)
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+)
+
+// FileStore is the storage backend tubely uses to persist uploaded assets
+// (videos, thumbnails, peaks, etc). Handlers should depend on this interface
+// instead of talking to a specific backend (S3, MinIO, local disk) directly.
+type FileStore interface {
+	// Put writes body to key, replacing any existing object at that key.
+	Put(ctx context.Context, key string, body io.Reader, contentType string) error
+	// Get opens the object at key for reading. Callers must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// PresignGet returns a time-limited URL a client can use to fetch key
+	// without further authentication.
+	PresignGet(ctx context.Context, key string, expires time.Duration) (string, error)
+	// Delete removes the object at key. It is not an error if key doesn't exist.
+	Delete(ctx context.Context, key string) error
+}
+
+// Backend identifies which FileStore implementation to construct.
+type Backend string
+
+const (
+	BackendS3    Backend = "s3"
+	BackendMinIO Backend = "minio"
+	BackendLocal Backend = "local"
+)
+
+// Config describes how to construct a FileStore. Only the fields relevant to
+// the selected Backend need to be set.
+type Config struct {
+	Backend Backend
+
+	// S3 / MinIO
+	Bucket          string
+	Region          string
+	Endpoint        string // MinIO only
+	AccessKeyID     string // MinIO only
+	SecretAccessKey string // MinIO only
+	UseSSL          bool   // MinIO only
+
+	// Local disk
+	LocalBaseDir    string
+	LocalPublicURL  string
+	LocalSignSecret string
+
+	// CloudFront delivers this backend's bucket through a CDN instead of
+	// presigning URLs against the bucket directly. Leave nil to disable.
+	CloudFront *CloudFrontConfig
+}
+
+// New constructs the FileStore selected by cfg.Backend. It's intended to be
+// called once at startup so the rest of the app never has to know which
+// backend is active.
+func New(ctx context.Context, cfg Config) (FileStore, error) {
+	var store FileStore
+	var err error
+
+	switch cfg.Backend {
+	case BackendS3:
+		store, err = NewS3Store(ctx, cfg.Bucket, cfg.Region)
+	case BackendMinIO:
+		store, err = NewMinioStore(cfg.Endpoint, cfg.AccessKeyID, cfg.SecretAccessKey, cfg.Bucket, cfg.UseSSL)
+	case BackendLocal:
+		store, err = NewLocalStore(cfg.LocalBaseDir, cfg.LocalPublicURL, []byte(cfg.LocalSignSecret))
+	default:
+		return nil, fmt.Errorf("filestore: unknown backend %q", cfg.Backend)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.CloudFront != nil {
+		return NewCloudFrontStore(store, *cfg.CloudFront)
+	}
+	return store, nil
+}
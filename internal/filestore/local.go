@@ -0,0 +1,141 @@
+package filestore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LocalStore is a FileStore backed by the local filesystem. It's meant for
+// running tubely without an AWS account: PresignGet returns an HMAC-signed
+// URL served by the handler returned from SignedFileHandler.
+type LocalStore struct {
+	baseDir   string
+	publicURL string
+	secret    []byte
+}
+
+// NewLocalStore stores uploaded files under baseDir and signs URLs rooted at
+// publicURL (e.g. "http://localhost:8091/local-storage").
+func NewLocalStore(baseDir, publicURL string, secret []byte) (*LocalStore, error) {
+	if err := os.MkdirAll(baseDir, 0o755); err != nil {
+		return nil, fmt.Errorf("filestore: failed to create local store dir: %w", err)
+	}
+	return &LocalStore{
+		baseDir:   baseDir,
+		publicURL: strings.TrimRight(publicURL, "/"),
+		secret:    secret,
+	}, nil
+}
+
+func (l *LocalStore) path(key string) (string, error) {
+	base := filepath.Clean(l.baseDir)
+	cleaned := filepath.Join(base, key)
+	if !strings.HasPrefix(cleaned, base+string(filepath.Separator)) {
+		return "", fmt.Errorf("filestore: invalid key %q", key)
+	}
+	return cleaned, nil
+}
+
+func (l *LocalStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	dst, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return fmt.Errorf("filestore: failed to create directory for %q: %w", key, err)
+	}
+	f, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("filestore: failed to create %q: %w", key, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, body); err != nil {
+		return fmt.Errorf("filestore: failed to write %q: %w", key, err)
+	}
+	return nil
+}
+
+func (l *LocalStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	src, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, fmt.Errorf("filestore: failed to open %q: %w", key, err)
+	}
+	return f, nil
+}
+
+func (l *LocalStore) Delete(ctx context.Context, key string) error {
+	dst, err := l.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dst); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("filestore: failed to delete %q: %w", key, err)
+	}
+	return nil
+}
+
+// PresignGet returns a URL of the form
+// "<publicURL>/<key>?expires=<unix>&sig=<hmac>" that SignedFileHandler will
+// accept until expires elapses.
+func (l *LocalStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	expiresAt := time.Now().Add(expires).Unix()
+	sig := l.sign(key, expiresAt)
+
+	q := url.Values{}
+	q.Set("expires", strconv.FormatInt(expiresAt, 10))
+	q.Set("sig", sig)
+	return fmt.Sprintf("%s/%s?%s", l.publicURL, key, q.Encode()), nil
+}
+
+func (l *LocalStore) sign(key string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, l.secret)
+	fmt.Fprintf(mac, "%s:%d", key, expiresAt)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// SignedFileHandler serves files out of baseDir, rejecting requests whose
+// "expires"/"sig" query parameters don't match a URL produced by PresignGet.
+func (l *LocalStore) SignedFileHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := strings.TrimPrefix(r.URL.Path, "/")
+
+		expiresAt, err := strconv.ParseInt(r.URL.Query().Get("expires"), 10, 64)
+		if err != nil {
+			http.Error(w, "missing or invalid expires", http.StatusBadRequest)
+			return
+		}
+		if time.Now().Unix() > expiresAt {
+			http.Error(w, "url expired", http.StatusForbidden)
+			return
+		}
+
+		wantSig := l.sign(key, expiresAt)
+		gotSig := r.URL.Query().Get("sig")
+		if !hmac.Equal([]byte(wantSig), []byte(gotSig)) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+
+		src, err := l.path(key)
+		if err != nil {
+			http.Error(w, "invalid key", http.StatusBadRequest)
+			return
+		}
+		http.ServeFile(w, r, src)
+	})
+}
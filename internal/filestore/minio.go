@@ -0,0 +1,78 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// MinioStore is a FileStore backed by a MinIO-compatible (S3 API) server,
+// for self-hosted deployments that don't use AWS.
+type MinioStore struct {
+	client *minio.Client
+	bucket string
+}
+
+// NewMinioStore connects to the MinIO server at endpoint and returns a
+// FileStore backed by bucket, creating it if it doesn't already exist.
+func NewMinioStore(endpoint, accessKeyID, secretAccessKey, bucket string, useSSL bool) (*MinioStore, error) {
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKeyID, secretAccessKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("filestore: failed to create minio client: %w", err)
+	}
+
+	ctx := context.Background()
+	exists, err := client.BucketExists(ctx, bucket)
+	if err != nil {
+		return nil, fmt.Errorf("filestore: failed to check minio bucket: %w", err)
+	}
+	if !exists {
+		if err := client.MakeBucket(ctx, bucket, minio.MakeBucketOptions{}); err != nil {
+			return nil, fmt.Errorf("filestore: failed to create minio bucket: %w", err)
+		}
+	}
+
+	return &MinioStore{client: client, bucket: bucket}, nil
+}
+
+func (m *MinioStore) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	_, err := m.client.PutObject(ctx, m.bucket, key, body, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("filestore: minio put object: %w", err)
+	}
+	return nil
+}
+
+func (m *MinioStore) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	obj, err := m.client.GetObject(ctx, m.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("filestore: minio get object: %w", err)
+	}
+	return obj, nil
+}
+
+func (m *MinioStore) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	reqParams := url.Values{}
+	presigned, err := m.client.PresignedGetObject(ctx, m.bucket, key, expires, reqParams)
+	if err != nil {
+		return "", fmt.Errorf("filestore: minio presign get object: %w", err)
+	}
+	return presigned.String(), nil
+}
+
+func (m *MinioStore) Delete(ctx context.Context, key string) error {
+	if err := m.client.RemoveObject(ctx, m.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("filestore: minio delete object: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package filestore
+
+import (
+	"context"
+	"io"
+)
+
+// CompletedPart identifies one uploaded chunk of a multipart upload, built
+// from the ETag MultipartPutter.UploadPart returns and required by
+// CompleteMultipartUpload.
+type CompletedPart struct {
+	PartNumber int32
+	ETag       string
+}
+
+// MultipartPutter is implemented by backends that support resumable, chunked
+// uploads. Not every FileStore backend can: callers type-assert for it, and
+// the resumable upload handlers respond 501 Not Implemented when it isn't
+// available.
+type MultipartPutter interface {
+	CreateMultipartUpload(ctx context.Context, key, contentType string) (uploadID string, err error)
+	UploadPart(ctx context.Context, key, uploadID string, partNumber int32, body io.Reader) (etag string, err error)
+	CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error
+	AbortMultipartUpload(ctx context.Context, key, uploadID string) error
+}
@@ -0,0 +1,146 @@
+package filestore
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+)
+
+// S3Store is a FileStore backed by AWS S3.
+type S3Store struct {
+	client *s3.Client
+	bucket string
+}
+
+// NewS3Store loads the default AWS config (env vars, shared config, IAM role,
+// etc) and returns a FileStore backed by bucket in region.
+func NewS3Store(ctx context.Context, bucket, region string) (*S3Store, error) {
+	awsCfg, err := config.LoadDefaultConfig(ctx, config.WithRegion(region))
+	if err != nil {
+		return nil, fmt.Errorf("filestore: failed to load aws config: %w", err)
+	}
+	return &S3Store{
+		client: s3.NewFromConfig(awsCfg),
+		bucket: bucket,
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, body io.Reader, contentType string) error {
+	_, err := s.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:      &s.bucket,
+		Key:         &key,
+		Body:        body,
+		ContentType: &contentType,
+	})
+	if err != nil {
+		return fmt.Errorf("filestore: s3 put object: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("filestore: s3 get object: %w", err)
+	}
+	return out.Body, nil
+}
+
+func (s *S3Store) PresignGet(ctx context.Context, key string, expires time.Duration) (string, error) {
+	presignClient := s3.NewPresignClient(s.client)
+	presigned, err := presignClient.PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	}, s3.WithPresignExpires(expires))
+	if err != nil {
+		return "", fmt.Errorf("filestore: s3 presign get object: %w", err)
+	}
+	return presigned.URL, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: &s.bucket,
+		Key:    &key,
+	})
+	if err != nil {
+		return fmt.Errorf("filestore: s3 delete object: %w", err)
+	}
+	return nil
+}
+
+// CreateMultipartUpload, UploadPart, CompleteMultipartUpload, and
+// AbortMultipartUpload implement MultipartPutter on top of S3's native
+// multipart upload API, so large uploads can be streamed in chunks instead
+// of buffered in full before a single PutObject.
+
+func (s *S3Store) CreateMultipartUpload(ctx context.Context, key, contentType string) (string, error) {
+	out, err := s.client.CreateMultipartUpload(ctx, &s3.CreateMultipartUploadInput{
+		Bucket:      &s.bucket,
+		Key:         &key,
+		ContentType: &contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("filestore: s3 create multipart upload: %w", err)
+	}
+	return *out.UploadId, nil
+}
+
+func (s *S3Store) UploadPart(ctx context.Context, key, uploadID string, partNumber int32, body io.Reader) (string, error) {
+	out, err := s.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     &s.bucket,
+		Key:        &key,
+		UploadId:   &uploadID,
+		PartNumber: &partNumber,
+		Body:       body,
+	})
+	if err != nil {
+		return "", fmt.Errorf("filestore: s3 upload part %d: %w", partNumber, err)
+	}
+	return *out.ETag, nil
+}
+
+func (s *S3Store) CompleteMultipartUpload(ctx context.Context, key, uploadID string, parts []CompletedPart) error {
+	completedParts := make([]types.CompletedPart, len(parts))
+	for i, p := range parts {
+		partNumber := p.PartNumber
+		etag := p.ETag
+		completedParts[i] = types.CompletedPart{
+			PartNumber: &partNumber,
+			ETag:       &etag,
+		}
+	}
+
+	_, err := s.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:   &s.bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{
+			Parts: completedParts,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("filestore: s3 complete multipart upload: %w", err)
+	}
+	return nil
+}
+
+func (s *S3Store) AbortMultipartUpload(ctx context.Context, key, uploadID string) error {
+	_, err := s.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   &s.bucket,
+		Key:      &key,
+		UploadId: &uploadID,
+	})
+	if err != nil {
+		return fmt.Errorf("filestore: s3 abort multipart upload: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Package hls produces adaptive-bitrate HLS ladders from an uploaded video,
+// in place of the single faststart MP4 processVideoForFastStart writes.
+package hls
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Rendition is one quality level in an HLS ladder.
+type Rendition struct {
+	Name         string // e.g. "720p", also used as the variant playlist's base filename
+	Width        int
+	Height       int
+	VideoBitrate string // ffmpeg -b:v value, e.g. "2800k"
+	AudioBitrate string // ffmpeg -b:a value, e.g. "128k"
+}
+
+// LandscapeLadder is used for 16:9 (and other non-portrait) uploads.
+var LandscapeLadder = []Rendition{
+	{"240p", 426, 240, "400k", "64k"},
+	{"480p", 854, 480, "1000k", "96k"},
+	{"720p", 1280, 720, "2800k", "128k"},
+	{"1080p", 1920, 1080, "5000k", "160k"},
+}
+
+// PortraitLadder is used for 9:16 uploads; it's LandscapeLadder with width
+// and height swapped.
+var PortraitLadder = []Rendition{
+	{"240p", 240, 426, "400k", "64k"},
+	{"480p", 480, 854, "1000k", "96k"},
+	{"720p", 720, 1280, "2800k", "128k"},
+	{"1080p", 1080, 1920, "5000k", "160k"},
+}
+
+// SelectLadder returns the renditions of ladder that don't upscale past
+// sourceHeight. If sourceHeight is smaller than every rendition, the lowest
+// one is still returned so there's always at least one variant.
+func SelectLadder(ladder []Rendition, sourceHeight int) []Rendition {
+	var selected []Rendition
+	for _, r := range ladder {
+		if r.Height <= sourceHeight {
+			selected = append(selected, r)
+		}
+	}
+	if len(selected) == 0 {
+		selected = append(selected, ladder[0])
+	}
+	return selected
+}
+
+// Output is one file Transcode produced that still needs to be uploaded.
+type Output struct {
+	Key         string // prefixed with the prefix Transcode was called with
+	Path        string // local path to read it from
+	ContentType string
+}
+
+// Transcode runs one ffmpeg invocation per rendition in ladder, each writing
+// an HLS variant (a playlist plus its .ts segments) into outputDir, then
+// writes a master playlist referencing every variant. Keys on the returned
+// Outputs are prefixed with prefix, matching where the caller intends to
+// upload them. masterKey is the key of the master playlist among them.
+func Transcode(sourcePath, outputDir, prefix string, ladder []Rendition) (masterKey string, outputs []Output, err error) {
+	masterLines := []string{"#EXTM3U", "#EXT-X-VERSION:3"}
+
+	for _, r := range ladder {
+		playlistName := r.Name + ".m3u8"
+		segmentPattern := r.Name + "_%03d.ts"
+
+		cmd := exec.Command("ffmpeg",
+			"-i", sourcePath,
+			"-vf", fmt.Sprintf("scale=%d:%d", r.Width, r.Height),
+			"-c:v", "libx264",
+			"-b:v", r.VideoBitrate,
+			"-c:a", "aac",
+			"-b:a", r.AudioBitrate,
+			"-f", "hls",
+			"-hls_time", "6",
+			"-hls_playlist_type", "vod",
+			"-hls_segment_filename", filepath.Join(outputDir, segmentPattern),
+			filepath.Join(outputDir, playlistName),
+		)
+		if err := cmd.Run(); err != nil {
+			return "", nil, fmt.Errorf("error: ffmpeg hls rendition %s failed: %s", r.Name, err)
+		}
+
+		outputs = append(outputs, Output{
+			Key:         path.Join(prefix, playlistName),
+			Path:        filepath.Join(outputDir, playlistName),
+			ContentType: "application/vnd.apple.mpegurl",
+		})
+
+		segments, err := filepath.Glob(filepath.Join(outputDir, r.Name+"_*.ts"))
+		if err != nil {
+			return "", nil, fmt.Errorf("error: failed to list segments for rendition %s: %s", r.Name, err)
+		}
+		for _, seg := range segments {
+			outputs = append(outputs, Output{
+				Key:         path.Join(prefix, filepath.Base(seg)),
+				Path:        seg,
+				ContentType: "video/mp2t",
+			})
+		}
+
+		masterLines = append(masterLines,
+			fmt.Sprintf("#EXT-X-STREAM-INF:BANDWIDTH=%d,RESOLUTION=%dx%d", bandwidth(r), r.Width, r.Height),
+			playlistName,
+		)
+	}
+
+	masterPath := filepath.Join(outputDir, "master.m3u8")
+	if err := os.WriteFile(masterPath, []byte(strings.Join(masterLines, "\n")+"\n"), 0o644); err != nil {
+		return "", nil, fmt.Errorf("error: failed to write master playlist: %s", err)
+	}
+	masterKey = path.Join(prefix, "master.m3u8")
+	outputs = append(outputs, Output{
+		Key:         masterKey,
+		Path:        masterPath,
+		ContentType: "application/vnd.apple.mpegurl",
+	})
+
+	return masterKey, outputs, nil
+}
+
+func bandwidth(r Rendition) int {
+	return bitrate(r.VideoBitrate) + bitrate(r.AudioBitrate)
+}
+
+func bitrate(s string) int {
+	n, _ := strconv.Atoi(strings.TrimSuffix(s, "k"))
+	return n * 1000
+}
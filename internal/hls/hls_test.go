@@ -0,0 +1,38 @@
+package hls
+
+import "testing"
+
+func TestSelectLadderDropsUpscaledRenditions(t *testing.T) {
+	selected := SelectLadder(LandscapeLadder, 480)
+
+	for _, r := range selected {
+		if r.Height > 480 {
+			t.Errorf("SelectLadder(_, 480) returned rendition %s with height %d > 480", r.Name, r.Height)
+		}
+	}
+	if len(selected) == 0 {
+		t.Fatal("SelectLadder(_, 480) returned no renditions")
+	}
+	if selected[len(selected)-1].Name != "480p" {
+		t.Errorf("expected 480p to be the highest selected rendition, got %s", selected[len(selected)-1].Name)
+	}
+}
+
+func TestSelectLadderAlwaysReturnsAtLeastOneRendition(t *testing.T) {
+	selected := SelectLadder(LandscapeLadder, 100)
+
+	if len(selected) != 1 {
+		t.Fatalf("expected exactly one rendition when sourceHeight is below every rung, got %d", len(selected))
+	}
+	if selected[0].Name != LandscapeLadder[0].Name {
+		t.Errorf("expected the lowest rendition %s, got %s", LandscapeLadder[0].Name, selected[0].Name)
+	}
+}
+
+func TestSelectLadderIncludesExactMatch(t *testing.T) {
+	selected := SelectLadder(LandscapeLadder, 1080)
+
+	if len(selected) != len(LandscapeLadder) {
+		t.Fatalf("expected all %d renditions when sourceHeight matches the top rung, got %d", len(LandscapeLadder), len(selected))
+	}
+}
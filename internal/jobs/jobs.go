@@ -0,0 +1,52 @@
+// Package jobs implements the background transcode-and-upload pipeline so
+// handlerUploadVideo doesn't have to block the HTTP request for the whole
+// ffmpeg + upload cycle.
+package jobs
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Stage identifies where a job currently is in the pipeline.
+type Stage string
+
+const (
+	StageQueued      Stage = "queued"
+	StageTranscoding Stage = "transcoding"
+	StageUploading   Stage = "uploading"
+	StageDone        Stage = "done"
+	StageFailed      Stage = "failed"
+)
+
+// Status is the persisted, terminal-or-not state of a job row.
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// TranscodeAndUploadJob carries everything a worker needs to faststart,
+// classify, and upload a single uploaded video.
+type TranscodeAndUploadJob struct {
+	ID         string
+	VideoID    uuid.UUID
+	UserID     uuid.UUID
+	SourcePath string
+	MediaType  string
+	Attempt    int
+	CreatedAt  time.Time
+}
+
+// Progress is one update in a job's lifecycle, streamed to clients polling
+// GET /api/videos/{id}/upload_progress.
+type Progress struct {
+	PercentComplete float64 `json:"percent_complete"`
+	Stage           Stage   `json:"stage"`
+	URL             string  `json:"url,omitempty"`
+	Error           string  `json:"error,omitempty"`
+}
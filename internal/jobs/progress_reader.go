@@ -0,0 +1,30 @@
+package jobs
+
+import "io"
+
+// ProgressReader wraps an io.Reader of known total size and calls onProgress
+// after every Read that returns data, so a single source can feed both an ffmpeg stdin pipe
+// and an S3 upload body while reporting byte-level progress for either.
+type ProgressReader struct {
+	r          io.Reader
+	total      int64
+	read       int64
+	onProgress func(read, total int64)
+}
+
+// NewProgressReader returns a reader that reports progress against total as
+// r is consumed. total may be 0 if the size isn't known in advance.
+func NewProgressReader(r io.Reader, total int64, onProgress func(read, total int64)) *ProgressReader {
+	return &ProgressReader{r: r, total: total, onProgress: onProgress}
+}
+
+func (p *ProgressReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	if n > 0 {
+		p.read += int64(n)
+		if p.onProgress != nil {
+			p.onProgress(p.read, p.total)
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,173 @@
+package jobs
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ProcessFunc does the actual transcode + upload work for a job, calling
+// report as progress is made. It returns the final asset URL on success.
+type ProcessFunc func(ctx context.Context, job TranscodeAndUploadJob, report func(Progress)) (url string, err error)
+
+const maxAttempts = 5
+
+// Queue is an in-memory worker pool that runs TranscodeAndUploadJobs,
+// persisting their status to Store and retrying failures with exponential
+// backoff. Progress updates are fanned out to subscribers of Subscribe so
+// the SSE endpoint can stream them to clients.
+type Queue struct {
+	store   Store
+	process ProcessFunc
+
+	jobs chan TranscodeAndUploadJob
+
+	mu   sync.Mutex
+	subs map[string][]chan Progress
+	last map[string]Progress
+}
+
+// NewQueue starts workers goroutines, each pulling jobs off an internal
+// channel until ctx is cancelled.
+func NewQueue(ctx context.Context, workers int, store Store, process ProcessFunc) *Queue {
+	q := &Queue{
+		store:   store,
+		process: process,
+		jobs:    make(chan TranscodeAndUploadJob, 64),
+		subs:    make(map[string][]chan Progress),
+		last:    make(map[string]Progress),
+	}
+	for i := 0; i < workers; i++ {
+		go q.worker(ctx)
+	}
+	return q
+}
+
+// Enqueue persists job and schedules it to run on the next free worker.
+func (q *Queue) Enqueue(job TranscodeAndUploadJob) error {
+	job.ID = uuid.NewString()
+	job.CreatedAt = time.Now()
+	if err := q.store.CreateJob(job); err != nil {
+		return err
+	}
+	q.jobs <- job
+	return nil
+}
+
+// Job returns the persisted job row for id, so callers can check things like
+// ownership before subscribing to its progress.
+func (q *Queue) Job(id string) (TranscodeAndUploadJob, Status, error) {
+	return q.store.GetJob(id)
+}
+
+// Subscribe returns a channel of progress updates for jobID and an unsubscribe
+// func the caller must call when done listening. If a progress update has
+// already been published for jobID, it's replayed immediately so a late
+// subscriber doesn't miss the current state. A terminal update published
+// while nobody was subscribed has already been dropped by forgetIfDone, so
+// subscribing after that replays nothing.
+func (q *Queue) Subscribe(jobID string) (<-chan Progress, func()) {
+	ch := make(chan Progress, 16)
+
+	q.mu.Lock()
+	q.subs[jobID] = append(q.subs[jobID], ch)
+	if last, ok := q.last[jobID]; ok {
+		ch <- last
+	}
+	q.mu.Unlock()
+
+	unsubscribe := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		subs := q.subs[jobID]
+		for i, c := range subs {
+			if c == ch {
+				q.subs[jobID] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(ch)
+		q.forgetIfDone(jobID)
+	}
+	return ch, unsubscribe
+}
+
+func (q *Queue) publish(jobID string, p Progress) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.last[jobID] = p
+	for _, ch := range q.subs[jobID] {
+		select {
+		case ch <- p:
+		default:
+			// slow subscriber, drop the update rather than block the worker
+		}
+	}
+	q.forgetIfDone(jobID)
+}
+
+// forgetIfDone drops jobID's last-progress and subscriber-list entries once
+// it has reached a terminal stage and every subscriber has unsubscribed, so
+// a long-running server doesn't accumulate one entry per job forever. Callers
+// must hold q.mu.
+func (q *Queue) forgetIfDone(jobID string) {
+	last, ok := q.last[jobID]
+	if !ok || len(q.subs[jobID]) > 0 {
+		return
+	}
+	if last.Stage == StageDone || last.Stage == StageFailed {
+		delete(q.last, jobID)
+		delete(q.subs, jobID)
+	}
+}
+
+func (q *Queue) worker(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case job := <-q.jobs:
+			q.runJob(ctx, job)
+		}
+	}
+}
+
+func (q *Queue) runJob(ctx context.Context, job TranscodeAndUploadJob) {
+	job.Attempt++
+	if err := q.store.UpdateJobStatus(job.ID, StatusRunning, job.Attempt, ""); err != nil {
+		log.Printf("jobs: failed to mark job %s running: %v", job.ID, err)
+	}
+	q.publish(job.ID, Progress{Stage: StageTranscoding})
+
+	url, err := q.process(ctx, job, func(p Progress) {
+		q.publish(job.ID, p)
+	})
+	if err != nil {
+		if job.Attempt < maxAttempts {
+			backoff := time.Duration(1<<uint(job.Attempt)) * time.Second
+			log.Printf("jobs: job %s failed (attempt %d/%d), retrying in %s: %v", job.ID, job.Attempt, maxAttempts, backoff, err)
+			if uerr := q.store.UpdateJobStatus(job.ID, StatusQueued, job.Attempt, err.Error()); uerr != nil {
+				log.Printf("jobs: failed to mark job %s queued for retry: %v", job.ID, uerr)
+			}
+			time.AfterFunc(backoff, func() {
+				q.jobs <- job
+			})
+			return
+		}
+
+		log.Printf("jobs: job %s failed permanently after %d attempts: %v", job.ID, job.Attempt, err)
+		if uerr := q.store.UpdateJobStatus(job.ID, StatusFailed, job.Attempt, err.Error()); uerr != nil {
+			log.Printf("jobs: failed to mark job %s failed: %v", job.ID, uerr)
+		}
+		q.publish(job.ID, Progress{Stage: StageFailed, Error: err.Error()})
+		return
+	}
+
+	if err := q.store.UpdateJobStatus(job.ID, StatusSucceeded, job.Attempt, ""); err != nil {
+		log.Printf("jobs: failed to mark job %s succeeded: %v", job.ID, err)
+	}
+	q.publish(job.ID, Progress{PercentComplete: 100, Stage: StageDone, URL: url})
+}
@@ -0,0 +1,167 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+// fakeStore is an in-memory Store for exercising Queue without a database.
+type fakeStore struct {
+	mu       sync.Mutex
+	jobs     map[string]TranscodeAndUploadJob
+	statuses map[string]Status
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		jobs:     make(map[string]TranscodeAndUploadJob),
+		statuses: make(map[string]Status),
+	}
+}
+
+func (s *fakeStore) CreateJob(job TranscodeAndUploadJob) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	s.statuses[job.ID] = StatusQueued
+	return nil
+}
+
+func (s *fakeStore) UpdateJobStatus(id string, status Status, attempt int, lastErr string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[id]
+	job.Attempt = attempt
+	s.jobs[id] = job
+	s.statuses[id] = status
+	return nil
+}
+
+func (s *fakeStore) GetJob(id string) (TranscodeAndUploadJob, Status, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return TranscodeAndUploadJob{}, "", errors.New("jobs: job not found")
+	}
+	return job, s.statuses[id], nil
+}
+
+// newTestQueue builds a Queue with no worker goroutines, so runJob can be
+// called directly and deterministically instead of racing the real pool.
+func newTestQueue(process ProcessFunc) (*Queue, *fakeStore) {
+	store := newFakeStore()
+	q := NewQueue(context.Background(), 0, store, process)
+	return q, store
+}
+
+func TestRunJobSucceeds(t *testing.T) {
+	job := TranscodeAndUploadJob{ID: "job-success"}
+	q, store := newTestQueue(func(ctx context.Context, job TranscodeAndUploadJob, report func(Progress)) (string, error) {
+		report(Progress{PercentComplete: 50, Stage: StageUploading})
+		return "https://example.com/video.mp4", nil
+	})
+	store.CreateJob(job)
+	// Stay subscribed so the terminal update isn't pruned from q.last.
+	_, unsubscribe := q.Subscribe(job.ID)
+	defer unsubscribe()
+
+	q.runJob(context.Background(), job)
+
+	if _, status, err := store.GetJob(job.ID); err != nil || status != StatusSucceeded {
+		t.Fatalf("GetJob status = %v, err = %v; want %v, nil", status, err, StatusSucceeded)
+	}
+	q.mu.Lock()
+	last := q.last[job.ID]
+	q.mu.Unlock()
+	if last.Stage != StageDone || last.URL != "https://example.com/video.mp4" {
+		t.Errorf("last progress = %+v, want Stage=%q URL set", last, StageDone)
+	}
+}
+
+func TestRunJobFailsPermanentlyAfterMaxAttempts(t *testing.T) {
+	job := TranscodeAndUploadJob{ID: "job-permanent-fail", Attempt: maxAttempts - 1}
+	q, store := newTestQueue(func(ctx context.Context, job TranscodeAndUploadJob, report func(Progress)) (string, error) {
+		return "", errors.New("boom")
+	})
+	store.CreateJob(job)
+	// Stay subscribed so the terminal update isn't pruned from q.last.
+	_, unsubscribe := q.Subscribe(job.ID)
+	defer unsubscribe()
+
+	q.runJob(context.Background(), job)
+
+	if _, status, err := store.GetJob(job.ID); err != nil || status != StatusFailed {
+		t.Fatalf("GetJob status = %v, err = %v; want %v, nil", status, err, StatusFailed)
+	}
+	q.mu.Lock()
+	last := q.last[job.ID]
+	q.mu.Unlock()
+	if last.Stage != StageFailed || last.Error != "boom" {
+		t.Errorf("last progress = %+v, want Stage=%q Error=%q", last, StageFailed, "boom")
+	}
+}
+
+func TestRunJobRetriesBeforeMaxAttempts(t *testing.T) {
+	job := TranscodeAndUploadJob{ID: "job-retry"}
+	q, store := newTestQueue(func(ctx context.Context, job TranscodeAndUploadJob, report func(Progress)) (string, error) {
+		return "", errors.New("transient")
+	})
+	store.CreateJob(job)
+
+	q.runJob(context.Background(), job)
+
+	if _, status, err := store.GetJob(job.ID); err != nil || status != StatusQueued {
+		t.Fatalf("GetJob status = %v, err = %v; want %v, nil", status, err, StatusQueued)
+	}
+}
+
+func TestSubscribePublishUnsubscribePrunesState(t *testing.T) {
+	q, _ := newTestQueue(nil)
+	const jobID = "job-subscribed"
+
+	ch, unsubscribe := q.Subscribe(jobID)
+	q.publish(jobID, Progress{Stage: StageDone, URL: "https://example.com/video.mp4"})
+
+	select {
+	case p := <-ch:
+		if p.Stage != StageDone {
+			t.Fatalf("received progress %+v, want Stage=%q", p, StageDone)
+		}
+	default:
+		t.Fatal("expected the terminal progress update to be delivered to the subscriber")
+	}
+
+	q.mu.Lock()
+	_, hasLast := q.last[jobID]
+	q.mu.Unlock()
+	if !hasLast {
+		t.Fatal("expected last progress to still be retained while a subscriber is attached")
+	}
+
+	unsubscribe()
+
+	q.mu.Lock()
+	_, hasLast = q.last[jobID]
+	_, hasSubs := q.subs[jobID]
+	q.mu.Unlock()
+	if hasLast || hasSubs {
+		t.Errorf("expected queue state for %q to be pruned after the last subscriber unsubscribed, got last=%v subs=%v", jobID, hasLast, hasSubs)
+	}
+}
+
+func TestPublishPrunesTerminalStateWithNoSubscribers(t *testing.T) {
+	q, _ := newTestQueue(nil)
+	const jobID = "job-never-subscribed"
+
+	q.publish(jobID, Progress{Stage: StageDone})
+
+	q.mu.Lock()
+	_, hasLast := q.last[jobID]
+	q.mu.Unlock()
+	if hasLast {
+		t.Errorf("expected terminal progress with no subscribers to be pruned immediately, found %q in q.last", jobID)
+	}
+}
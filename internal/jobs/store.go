@@ -0,0 +1,104 @@
+package jobs
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Store persists job rows so they can be looked up by the progress endpoint.
+// Rows outlive a server restart, but jobs that were queued or running at the
+// time are not re-enqueued when the server starts again.
+type Store interface {
+	CreateJob(job TranscodeAndUploadJob) error
+	UpdateJobStatus(id string, status Status, attempt int, lastErr string) error
+	GetJob(id string) (TranscodeAndUploadJob, Status, error)
+}
+
+// SQLiteStore is a Store backed by the server's SQLite database.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteStore opens db and makes sure the jobs table exists.
+func NewSQLiteStore(db *sql.DB) (*SQLiteStore, error) {
+	s := &SQLiteStore{db: db}
+	if err := s.migrate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *SQLiteStore) migrate() error {
+	_, err := s.db.Exec(`
+		CREATE TABLE IF NOT EXISTS transcode_jobs (
+			id TEXT PRIMARY KEY,
+			video_id TEXT NOT NULL,
+			user_id TEXT NOT NULL,
+			source_path TEXT NOT NULL,
+			media_type TEXT NOT NULL,
+			status TEXT NOT NULL,
+			attempt INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			created_at DATETIME NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("jobs: failed to create transcode_jobs table: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) CreateJob(job TranscodeAndUploadJob) error {
+	_, err := s.db.Exec(
+		`INSERT INTO transcode_jobs (id, video_id, user_id, source_path, media_type, status, attempt, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.ID, job.VideoID.String(), job.UserID.String(), job.SourcePath, job.MediaType,
+		StatusQueued, job.Attempt, job.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("jobs: failed to insert job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) UpdateJobStatus(id string, status Status, attempt int, lastErr string) error {
+	_, err := s.db.Exec(
+		`UPDATE transcode_jobs SET status = ?, attempt = ?, last_error = ? WHERE id = ?`,
+		status, attempt, lastErr, id,
+	)
+	if err != nil {
+		return fmt.Errorf("jobs: failed to update job %s: %w", id, err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) GetJob(id string) (TranscodeAndUploadJob, Status, error) {
+	var job TranscodeAndUploadJob
+	var videoID, userID string
+	var status Status
+	var createdAt time.Time
+
+	row := s.db.QueryRow(
+		`SELECT id, video_id, user_id, source_path, media_type, status, attempt, created_at
+		 FROM transcode_jobs WHERE id = ?`, id,
+	)
+	err := row.Scan(&job.ID, &videoID, &userID, &job.SourcePath, &job.MediaType, &status, &job.Attempt, &createdAt)
+	if err != nil {
+		return TranscodeAndUploadJob{}, "", fmt.Errorf("jobs: failed to load job %s: %w", id, err)
+	}
+
+	job.VideoID, err = uuid.Parse(videoID)
+	if err != nil {
+		return TranscodeAndUploadJob{}, "", fmt.Errorf("jobs: invalid video id on job %s: %w", id, err)
+	}
+	job.UserID, err = uuid.Parse(userID)
+	if err != nil {
+		return TranscodeAndUploadJob{}, "", fmt.Errorf("jobs: invalid user id on job %s: %w", id, err)
+	}
+	job.CreatedAt = createdAt
+
+	return job, status, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os/exec"
+)
+
+// peaksBucketCount is the number of min/max sample pairs generatePeaks
+// downsamples each video's audio into, regardless of its duration. Audio with
+// fewer samples than this gets one bucket per sample instead.
+const peaksBucketCount = 1000
+
+// generatePeaks decodes filePath's audio track to mono s16le PCM at 48kHz via
+// ffmpeg, then downsamples it into peaksBucketCount buckets. Each bucket is
+// encoded as two little-endian int16s (min sample, max sample), giving the
+// frontend enough data to render a scrubbable waveform without downloading
+// the whole video.
+func generatePeaks(filePath string) ([]byte, error) {
+	cmdPtr := exec.Command("ffmpeg",
+		"-i", filePath,
+		"-f", "s16le",
+		"-acodec", "pcm_s16le",
+		"-ar", "48000",
+		"-ac", "1",
+		"-",
+	)
+
+	var pcm bytes.Buffer
+	cmdPtr.Stdout = &pcm
+	if err := cmdPtr.Run(); err != nil {
+		return nil, fmt.Errorf("error: ffmpeg failed to decode audio for peaks: %s", err)
+	}
+
+	return peaksFromPCM(pcm.Bytes())
+}
+
+// peaksFromPCM downsamples mono s16le PCM samples into peaksBucketCount
+// min/max buckets, split out from generatePeaks so the bucket math can be
+// tested without shelling out to ffmpeg.
+func peaksFromPCM(samples []byte) ([]byte, error) {
+	sampleCount := len(samples) / 2
+	if sampleCount == 0 {
+		return nil, errors.New("error: no audio samples decoded")
+	}
+
+	bucketSize := sampleCount / peaksBucketCount
+	if bucketSize == 0 {
+		bucketSize = 1
+	}
+
+	peaks := make([]byte, 0, peaksBucketCount*4)
+	for bucket := 0; bucket*bucketSize < sampleCount; bucket++ {
+		start := bucket * bucketSize
+		end := start + bucketSize
+		if end > sampleCount || bucket == peaksBucketCount-1 {
+			end = sampleCount
+		}
+
+		min := int16(binary.LittleEndian.Uint16(samples[start*2 : start*2+2]))
+		max := min
+		for i := start; i < end; i++ {
+			sample := int16(binary.LittleEndian.Uint16(samples[i*2 : i*2+2]))
+			if sample < min {
+				min = sample
+			}
+			if sample > max {
+				max = sample
+			}
+		}
+
+		var buf [4]byte
+		binary.LittleEndian.PutUint16(buf[0:2], uint16(min))
+		binary.LittleEndian.PutUint16(buf[2:4], uint16(max))
+		peaks = append(peaks, buf[:]...)
+
+		if end == sampleCount {
+			break
+		}
+	}
+
+	return peaks, nil
+}
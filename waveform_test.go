@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func encodeSamples(samples []int16) []byte {
+	buf := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(buf[i*2:i*2+2], uint16(s))
+	}
+	return buf
+}
+
+func decodePeaks(t *testing.T, peaks []byte) [][2]int16 {
+	t.Helper()
+	if len(peaks)%4 != 0 {
+		t.Fatalf("peaks length %d is not a multiple of 4", len(peaks))
+	}
+	buckets := make([][2]int16, 0, len(peaks)/4)
+	for i := 0; i+4 <= len(peaks); i += 4 {
+		min := int16(binary.LittleEndian.Uint16(peaks[i : i+2]))
+		max := int16(binary.LittleEndian.Uint16(peaks[i+2 : i+4]))
+		buckets = append(buckets, [2]int16{min, max})
+	}
+	return buckets
+}
+
+func TestPeaksFromPCMNoSamples(t *testing.T) {
+	if _, err := peaksFromPCM(nil); err == nil {
+		t.Fatal("expected an error for empty PCM input")
+	}
+}
+
+func TestPeaksFromPCMFewerSamplesThanBuckets(t *testing.T) {
+	samples := []int16{-100, 50, 200, -300}
+	peaks, err := peaksFromPCM(encodeSamples(samples))
+	if err != nil {
+		t.Fatalf("peaksFromPCM returned error: %v", err)
+	}
+
+	buckets := decodePeaks(t, peaks)
+	if len(buckets) != len(samples) {
+		t.Fatalf("expected one bucket per sample when sampleCount < peaksBucketCount, got %d buckets for %d samples", len(buckets), len(samples))
+	}
+	for i, s := range samples {
+		if buckets[i][0] != s || buckets[i][1] != s {
+			t.Errorf("bucket %d = %v, want min=max=%d", i, buckets[i], s)
+		}
+	}
+}
+
+func TestPeaksFromPCMTracksMinMaxPerBucket(t *testing.T) {
+	// Two samples per bucket; the first and last buckets hold min/max values
+	// that are easy to check by hand, the rest are all zero.
+	samples := make([]int16, peaksBucketCount*2)
+	samples[0] = -500
+	samples[1] = 700
+	samples[len(samples)-2] = -1200
+	samples[len(samples)-1] = 900
+
+	peaks, err := peaksFromPCM(encodeSamples(samples))
+	if err != nil {
+		t.Fatalf("peaksFromPCM returned error: %v", err)
+	}
+
+	buckets := decodePeaks(t, peaks)
+	if len(buckets) != peaksBucketCount {
+		t.Fatalf("expected %d buckets, got %d", peaksBucketCount, len(buckets))
+	}
+	if buckets[0][0] != -500 || buckets[0][1] != 700 {
+		t.Errorf("first bucket = %v, want min=-500 max=700", buckets[0])
+	}
+	last := buckets[len(buckets)-1]
+	if last[0] != -1200 || last[1] != 900 {
+		t.Errorf("last bucket = %v, want min=-1200 max=900", last)
+	}
+}